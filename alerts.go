@@ -0,0 +1,300 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// AlertConfig controls the out-of-band price-move alerts: pushes that
+// fire as soon as a move crosses a threshold, independent of the
+// 03:00/08:00 publish cron.
+type AlertConfig struct {
+	Enabled bool `json:"enabled"`
+	// Thresholds is keyed by GoldPrice.Type; the "*" entry is the
+	// default applied to any type without its own entry.
+	Thresholds map[string]AlertThreshold `json:"thresholds"`
+	// SpreadVND alerts when Sell-Buy widens past this many thousand-VND
+	// units within any configured window. Zero/unset disables the check.
+	SpreadVND  decimal.Decimal `json:"spread_vnd"`
+	QuietHours QuietHours      `json:"quiet_hours"`
+}
+
+// AlertThreshold is the move that triggers an alert for one gold type,
+// evaluated independently over every window.
+type AlertThreshold struct {
+	PercentMove float64 `json:"percent_move"`
+	// VNDMove is in thousand-VND units, matching GoldPrice.Buy/Sell.
+	VNDMove decimal.Decimal `json:"vnd_move"`
+	// Windows are rolling lookbacks like "1h", "6h", "24h", parsed with
+	// time.ParseDuration.
+	Windows []string `json:"windows"`
+}
+
+// QuietHours suppresses alerts (but not the scheduled publish) between
+// Start and End, both "HH:MM" in local time. End before Start wraps
+// past midnight.
+type QuietHours struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+func (q QuietHours) contains(t time.Time) bool {
+	if q.Start == "" || q.End == "" {
+		return false
+	}
+	start, err1 := time.ParseDuration(hhmmToGoDuration(q.Start))
+	end, err2 := time.ParseDuration(hhmmToGoDuration(q.End))
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	sinceMidnight := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+
+	if start <= end {
+		return sinceMidnight >= start && sinceMidnight < end
+	}
+	// Wraps past midnight, e.g. 22:00 -> 07:00.
+	return sinceMidnight >= start || sinceMidnight < end
+}
+
+func hhmmToGoDuration(hhmm string) string {
+	var h, m int
+	if _, err := fmt.Sscanf(hhmm, "%d:%d", &h, &m); err != nil {
+		return "0s"
+	}
+	return fmt.Sprintf("%dh%dm", h, m)
+}
+
+// AlertManager evaluates rolling-window price moves and de-duplicates
+// so the same threshold crossing isn't sent on every fetch cycle.
+type AlertManager struct {
+	mu     sync.Mutex
+	sentAt map[string]time.Time
+}
+
+func NewAlertManager() *AlertManager {
+	return &AlertManager{sentAt: make(map[string]time.Time)}
+}
+
+// Check compares the latest prices against SQLite history over each
+// configured window and returns the alert messages that should be
+// sent. It is safe to call on every fetch cycle: quiet hours and
+// de-duplication are handled internally.
+func (m *AlertManager) Check(cfg Config, prices []GoldPrice) []string {
+	if !cfg.Alerts.Enabled {
+		return nil
+	}
+	if cfg.Alerts.QuietHours.contains(time.Now()) {
+		return nil
+	}
+
+	db, err := sql.Open("sqlite3", "gold.db")
+	if err != nil {
+		log.Println("Lỗi mở DB cho cảnh báo:", err)
+		return nil
+	}
+	defer db.Close()
+
+	var messages []string
+	for _, p := range prices {
+		threshold, ok := cfg.Alerts.Thresholds[p.Type]
+		if !ok {
+			threshold, ok = cfg.Alerts.Thresholds["*"]
+		}
+		if !ok {
+			continue
+		}
+		windows := threshold.Windows
+		if len(windows) == 0 {
+			windows = []string{"1h", "6h", "24h"}
+		}
+
+		for _, w := range windows {
+			ago, err := time.ParseDuration(w)
+			if err != nil {
+				log.Printf("⚠️  Cửa sổ cảnh báo không hợp lệ %q: %v", w, err)
+				continue
+			}
+			prior, err := priceAt(db, p.Type, ago)
+			if err != nil || prior == nil {
+				continue
+			}
+			elapsed := formatElapsed(time.Since(prior.UpdatedAt))
+
+			if msg, key, ok := m.checkMove(p, *prior, threshold, w, elapsed); ok {
+				if m.markIfFresh(key, ago) {
+					messages = append(messages, msg)
+				}
+			}
+			if msg, key, ok := m.checkSpread(cfg, p, *prior, w, elapsed); ok {
+				if m.markIfFresh(key, ago) {
+					messages = append(messages, msg)
+				}
+			}
+		}
+	}
+	return messages
+}
+
+// subscriptionAlert is a chat-targeted alert produced by
+// CheckSubscriptions, to be sent to ChatID rather than cfg.TelegramChatID.
+type subscriptionAlert struct {
+	ChatID  string
+	Message string
+}
+
+// subscriptionWindow is the fixed lookback CheckSubscriptions compares
+// against. Unlike Check's per-threshold Windows, /subscribe only stores
+// a single VND amount, so one window keeps the comparison unambiguous.
+const subscriptionWindow = 24 * time.Hour
+
+// CheckSubscriptions evaluates every /subscribe registration against the
+// trailing subscriptionWindow buy-price move and returns the chat-
+// targeted alerts that should be sent. It shares AlertManager's
+// de-duplication so a chat isn't re-alerted on every fetch cycle.
+func (m *AlertManager) CheckSubscriptions(prices []GoldPrice) []subscriptionAlert {
+	subs, err := loadSubscriptions()
+	if err != nil || len(subs) == 0 {
+		return nil
+	}
+
+	db, err := sql.Open("sqlite3", "gold.db")
+	if err != nil {
+		log.Println("Lỗi mở DB cho cảnh báo đăng ký:", err)
+		return nil
+	}
+	defer db.Close()
+
+	byType := make(map[string]GoldPrice, len(prices))
+	for _, p := range prices {
+		byType[p.Type] = p
+	}
+
+	var alerts []subscriptionAlert
+	for _, sub := range subs {
+		p, ok := byType[sub.GoldType]
+		if !ok {
+			continue
+		}
+		prior, err := priceAt(db, sub.GoldType, subscriptionWindow)
+		if err != nil || prior == nil {
+			continue
+		}
+
+		move := p.Buy.Sub(prior.Buy)
+		if move.Abs().LessThan(sub.Threshold) {
+			continue
+		}
+		key := fmt.Sprintf("sub:%s:%s", sub.ChatID, sub.GoldType)
+		if !m.markIfFresh(key, subscriptionWindow) {
+			continue
+		}
+		alerts = append(alerts, subscriptionAlert{
+			ChatID:  sub.ChatID,
+			Message: formatMoveAlert(sub.GoldType, "mua", "24h", move, prior.Buy),
+		})
+	}
+	return alerts
+}
+
+// checkMove evaluates one rolling window. window is the configured
+// lookback (e.g. "1h") and is only used for the de-dup key; elapsed is
+// the actual age of the prior row and is what gets shown in the alert
+// text, since the nearest prior row can be much older than window under
+// a sparse publish schedule.
+func (m *AlertManager) checkMove(p, prior GoldPrice, threshold AlertThreshold, window, elapsed string) (msg, key string, ok bool) {
+	buyMove := p.Buy.Sub(prior.Buy)
+	sellMove := p.Sell.Sub(prior.Sell)
+
+	if crossed(buyMove, prior.Buy, threshold) {
+		return formatMoveAlert(p.Type, "mua", elapsed, buyMove, prior.Buy), fmt.Sprintf("%s:buy:%s", p.Type, window), true
+	}
+	if crossed(sellMove, prior.Sell, threshold) {
+		return formatMoveAlert(p.Type, "bán", elapsed, sellMove, prior.Sell), fmt.Sprintf("%s:sell:%s", p.Type, window), true
+	}
+	return "", "", false
+}
+
+func (m *AlertManager) checkSpread(cfg Config, p, prior GoldPrice, window, elapsed string) (msg, key string, ok bool) {
+	if !cfg.Alerts.SpreadVND.IsPositive() {
+		return "", "", false
+	}
+	spread := p.Sell.Sub(p.Buy)
+	priorSpread := prior.Sell.Sub(prior.Buy)
+	if spread.Sub(priorSpread).LessThan(cfg.Alerts.SpreadVND) {
+		return "", "", false
+	}
+	return formatSpreadAlert(p.Type, elapsed, priorSpread, spread), fmt.Sprintf("%s:spread:%s", p.Type, window), true
+}
+
+func crossed(move, base decimal.Decimal, threshold AlertThreshold) bool {
+	abs := move.Abs()
+	if threshold.VNDMove.IsPositive() && abs.GreaterThanOrEqual(threshold.VNDMove) {
+		return true
+	}
+	if threshold.PercentMove > 0 && !base.IsZero() {
+		pct := abs.Div(base.Abs()).Mul(decimal.NewFromInt(100))
+		if pct.GreaterThanOrEqual(decimal.NewFromFloat(threshold.PercentMove)) {
+			return true
+		}
+	}
+	return false
+}
+
+// markIfFresh returns true (and records the send) only if key hasn't
+// already fired within its own window — that's what stops the same
+// crossing from being re-sent on every subsequent fetch.
+func (m *AlertManager) markIfFresh(key string, window time.Duration) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if last, ok := m.sentAt[key]; ok && time.Since(last) < window {
+		return false
+	}
+	m.sentAt[key] = time.Now()
+	return true
+}
+
+func formatMoveAlert(goldType, side, window string, move, base decimal.Decimal) string {
+	direction := "tăng"
+	if move.IsNegative() {
+		direction = "giảm"
+	}
+	return fmt.Sprintf("🚨 %s: giá %s %s %s/chỉ trong %s\n", goldType, side, direction, FormatVND(move), window)
+}
+
+func formatSpreadAlert(goldType, window string, priorSpread, spread decimal.Decimal) string {
+	return fmt.Sprintf("🚨 %s: biên độ Mua-Bán giãn từ %s lên %s trong %s\n", goldType, FormatVND(priorSpread), FormatVND(spread), window)
+}
+
+// formatElapsed renders a duration as a rounded-to-the-hour Vietnamese
+// label ("3h", "< 1h") for the alert text, since the nearest prior row
+// under a sparse publish schedule rarely lands exactly on the
+// configured window.
+func formatElapsed(d time.Duration) string {
+	hours := int(d.Hours())
+	if hours < 1 {
+		return "< 1h"
+	}
+	return fmt.Sprintf("%dh", hours)
+}
+
+// priceAt returns the most recent recorded price for goldType at or
+// before now-ago, or nil if there's no history that far back yet.
+func priceAt(db *sql.DB, goldType string, ago time.Duration) (*GoldPrice, error) {
+	cutoff := time.Now().Add(-ago)
+	row := db.QueryRow(`SELECT type, buy, sell, converted, updated_at FROM gold_prices WHERE type = ? AND updated_at <= ? ORDER BY updated_at DESC LIMIT 1`,
+		goldType, cutoff)
+
+	var p GoldPrice
+	if err := row.Scan(&p.Type, &p.Buy, &p.Sell, &p.Converted, &p.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &p, nil
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// commentaryMaxChars bounds the prompt sent to the chat completions
+// endpoint so a long price history can't blow past the model's context
+// (or a pay-per-token budget) by accident.
+const commentaryMaxChars = 4000
+
+const commentaryHistoryDays = 7
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatCompletionRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+}
+
+type chatCompletionResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+// appendCommentary asks an OpenAI-compatible chat completions endpoint
+// for a short Vietnamese commentary on the last commentaryHistoryDays
+// of prices plus today's snapshot, and appends it to message. It's a
+// no-op when cfg.GptKey is empty. In dryRun mode it prints the prompt
+// instead of calling the API and returns message unchanged.
+func appendCommentary(cfg Config, message string, today []GoldPrice, dryRun bool) string {
+	if cfg.GptKey == "" {
+		return message
+	}
+
+	history, err := queryHistory("", time.Now().AddDate(0, 0, -commentaryHistoryDays), time.Now())
+	if err != nil {
+		log.Println("⚠️  Không lấy được lịch sử cho bình luận AI:", err)
+		return message
+	}
+
+	prompt := buildCommentaryPrompt(history, today)
+	if dryRun {
+		fmt.Println("--- [dry-run] prompt bình luận AI ---")
+		fmt.Println(prompt)
+		return message
+	}
+
+	commentary, err := requestCommentary(cfg, prompt)
+	if err != nil {
+		log.Println("⚠️  Lỗi lấy bình luận AI:", err)
+		return message
+	}
+	return message + "\n" + commentary
+}
+
+func buildCommentaryPrompt(history, today []GoldPrice) string {
+	var b strings.Builder
+	b.WriteString("Bạn là chuyên gia phân tích thị trường vàng Việt Nam. ")
+	b.WriteString("Dựa trên dữ liệu giá vàng dưới đây, hãy viết một đoạn bình luận ngắn gọn bằng tiếng Việt gồm: ")
+	b.WriteString("xu hướng chung, các thay đổi biên độ Mua-Bán đáng chú ý, và một lưu ý thận trọng cho người đọc.\n\n")
+
+	fmt.Fprintf(&b, "Lịch sử %d ngày qua:\n", commentaryHistoryDays)
+	for _, p := range history {
+		fmt.Fprintf(&b, "- %s | %s | Mua %s Bán %s\n", p.UpdatedAt.Format("02/01 15:04"), p.Type, p.Buy.String(), p.Sell.String())
+	}
+
+	b.WriteString("\nGiá hôm nay:\n")
+	for _, p := range today {
+		fmt.Fprintf(&b, "- %s | Mua %s Bán %s\n", p.Type, p.Buy.String(), p.Sell.String())
+	}
+
+	prompt := b.String()
+	if len(prompt) > commentaryMaxChars {
+		runes := []rune(prompt)
+		if len(runes) > commentaryMaxChars {
+			runes = runes[:commentaryMaxChars]
+		}
+		prompt = string(runes)
+	}
+	return prompt
+}
+
+// requestCommentary calls the chat completions endpoint with a small
+// retry-with-backoff loop, since this step is best-effort and shouldn't
+// block publishing the scheduled message for long.
+func requestCommentary(cfg Config, prompt string) (string, error) {
+	baseURL := cfg.GptBaseURL
+	if baseURL == "" {
+		baseURL = "https://api.openai.com/v1"
+	}
+	model := cfg.GptModel
+	if model == "" {
+		model = "gpt-4o-mini"
+	}
+
+	reqBody, err := json.Marshal(chatCompletionRequest{
+		Model:    model,
+		Messages: []chatMessage{{Role: "user", Content: prompt}},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var lastErr error
+	backoff := time.Second
+	for attempt := 0; attempt < 3; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+
+		commentary, err := doCommentaryRequest(baseURL, cfg.GptKey, reqBody)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return commentary, nil
+	}
+	return "", lastErr
+}
+
+func doCommentaryRequest(baseURL, apiKey string, reqBody []byte) (string, error) {
+	req, err := http.NewRequest(http.MethodPost, baseURL+"/chat/completions", bytes.NewReader(reqBody))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP %d: %s", res.StatusCode, body)
+	}
+
+	var parsed chatCompletionResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return "", err
+	}
+	if len(parsed.Choices) == 0 {
+		return "", fmt.Errorf("không có phản hồi từ API")
+	}
+	return strings.TrimSpace(parsed.Choices[0].Message.Content), nil
+}
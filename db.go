@@ -0,0 +1,108 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// schemaVersion bumps whenever gold_prices' column layout changes.
+// Version 2 moved buy/sell from TEXT to NUMERIC so shopspring/decimal
+// round-trips through the driver's Scan/Value instead of free-text.
+const schemaVersion = 2
+
+// migrateSQLite ensures gold_prices exists with NUMERIC buy/sell
+// columns, lazily rebuilding the table from any pre-decimal database
+// (which stored buy/sell as TEXT) the first time it's opened.
+func migrateSQLite(db *sql.DB) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_version (version INTEGER NOT NULL)`); err != nil {
+		return err
+	}
+
+	var version int
+	_ = db.QueryRow(`SELECT version FROM schema_version LIMIT 1`).Scan(&version)
+
+	if version >= schemaVersion {
+		return nil
+	}
+
+	if err := migrateToNumericColumns(db); err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(`DELETE FROM schema_version`); err != nil {
+		return err
+	}
+	_, err := db.Exec(`INSERT INTO schema_version (version) VALUES (?)`, schemaVersion)
+	return err
+}
+
+// migrateToNumericColumns rebuilds gold_prices with NUMERIC buy/sell
+// columns, reparsing any existing TEXT rows so old history survives the
+// switch to decimal.
+func migrateToNumericColumns(db *sql.DB) error {
+	var existingName string
+	hasOldTable := db.QueryRow(`SELECT name FROM sqlite_master WHERE type = 'table' AND name = 'gold_prices'`).Scan(&existingName) == nil
+
+	if _, err := db.Exec(`
+	CREATE TABLE IF NOT EXISTS gold_prices_v2 (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		type TEXT,
+		buy NUMERIC,
+		sell NUMERIC,
+		converted TEXT,
+		updated_at DATETIME
+	);`); err != nil {
+		return err
+	}
+
+	if hasOldTable {
+		if err := copyLegacyRows(db); err != nil {
+			return err
+		}
+		if _, err := db.Exec(`DROP TABLE gold_prices`); err != nil {
+			return err
+		}
+	}
+
+	_, err := db.Exec(`ALTER TABLE gold_prices_v2 RENAME TO gold_prices`)
+	return err
+}
+
+func copyLegacyRows(db *sql.DB) error {
+	rows, err := db.Query(`SELECT type, buy, sell, converted, updated_at FROM gold_prices`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	type legacyRow struct {
+		goldType, buy, sell, converted string
+		updatedAt                      time.Time
+	}
+	var legacy []legacyRow
+	for rows.Next() {
+		var r legacyRow
+		if err := rows.Scan(&r.goldType, &r.buy, &r.sell, &r.converted, &r.updatedAt); err != nil {
+			log.Println("⚠️  Bỏ qua dòng lịch sử không đọc được:", err)
+			continue
+		}
+		legacy = append(legacy, r)
+	}
+
+	for _, r := range legacy {
+		buy, errBuy := decimal.NewFromString(r.buy)
+		sell, errSell := decimal.NewFromString(r.sell)
+		if errBuy != nil || errSell != nil {
+			log.Printf("⚠️  Bỏ qua dòng lịch sử không hợp lệ: buy=%q sell=%q", r.buy, r.sell)
+			continue
+		}
+		if _, err := db.Exec(`INSERT INTO gold_prices_v2 (type, buy, sell, converted, updated_at) VALUES (?, ?, ?, ?, ?)`,
+			r.goldType, buy, sell, r.converted, r.updatedAt); err != nil {
+			log.Println("⚠️  Lỗi copy dòng lịch sử:", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package main
+
+// RingGoldType is the canonical label for "nhẫn trơn 9999" used in the
+// consensus output, the scheduled message, and the Telegram bot's
+// /chart command. Every source's raw row for this product aliases to
+// it, so consensus/fallback works even when a vendor's own wording
+// differs or the primary site goes away entirely.
+const RingGoldType = "Vàng nhẫn khâu 9999"
+
+// goldTypeAliases maps each source's raw scraped label to a canonical
+// type, keyed by source name then by the exact label that source
+// prints for that row. A source/label pair absent from this table
+// passes through unchanged, so existing per-vendor price types (SJC
+// miếng, etc.) keep their own distinct buckets.
+var goldTypeAliases = map[string]map[string]string{
+	"hoakimnguyen": {
+		"Vàng nhẫn khâu 9999": RingGoldType,
+	},
+	"sjc": {
+		"Nhẫn tròn trơn 9999": RingGoldType,
+	},
+	"doji": {
+		"Nhẫn tròn 9999 Hưng Thịnh Vượng": RingGoldType,
+	},
+	"pnj": {
+		"Vàng nhẫn trơn PNJ 999.9": RingGoldType,
+	},
+	"baotinminhchau": {
+		"Vàng nhẫn tròn trơn Rồng Thăng Long": RingGoldType,
+	},
+}
+
+// canonicalGoldType normalizes a raw scraped label from source into
+// the shared canonical type so the consensus registry (and anything
+// downstream that looks prices up by type) groups quotes for "the
+// same" gold together no matter which vendor supplied them.
+func canonicalGoldType(source, rawLabel string) string {
+	if alias, ok := goldTypeAliases[source][rawLabel]; ok {
+		return alias
+	}
+	return rawLabel
+}
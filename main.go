@@ -1,26 +1,32 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
-	"strconv"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
-	"github.com/PuerkitoBio/goquery"
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/shopspring/decimal"
 )
 
+// GoldPrice.Buy/Sell are thousand-VND units per chỉ, same as the
+// scraped tables, kept as decimal so a fractional chỉ price doesn't
+// round away precision the way int64 used to.
 type GoldPrice struct {
-	Type      string    `json:"type"`
-	Buy       int64     `json:"buy"`
-	Sell      int64     `json:"sell"`
-	Converted string    `json:"converted"`
-	UpdatedAt time.Time `json:"updated_at"`
+	Type      string          `json:"type"`
+	Buy       decimal.Decimal `json:"buy"`
+	Sell      decimal.Decimal `json:"sell"`
+	Converted string          `json:"converted"`
+	UpdatedAt time.Time       `json:"updated_at"`
 }
 
 type Config struct {
@@ -29,6 +35,31 @@ type Config struct {
 	SlackWebhook   string `json:"slack_webhook"`
 	FormatTime     string `json:"format_time"`
 	GptKey         string `json:"gpt_key"`
+	// GptModel and GptBaseURL configure the OpenAI-compatible chat
+	// completions endpoint used for the daily commentary. Both default
+	// to OpenAI's own values when empty.
+	GptModel   string `json:"gpt_model"`
+	GptBaseURL string `json:"gpt_base_url"`
+
+	// SourceWeights controls each GoldSource's influence on the
+	// consensus median, keyed by its Name(). Sources absent from the
+	// map default to a weight of 1.
+	SourceWeights map[string]float64 `json:"source_weights"`
+	// SourceTimeoutMs bounds how long the registry waits on any single
+	// source before excluding it from the consensus. Defaults to 5000.
+	SourceTimeoutMs int `json:"source_timeout_ms"`
+
+	Alerts AlertConfig `json:"alerts"`
+
+	// Schedules are cron expressions (robfig/cron format, with a
+	// required leading seconds field, e.g. "0 0 3 * * *" for 03:00)
+	// for when to run a fetch+publish cycle. Defaults to the original
+	// 03:00/08:00 schedule if empty.
+	Schedules []string `json:"schedules"`
+
+	// APIAddr is the listen address for the HTTP API and /metrics
+	// endpoint. Defaults to ":9090" if empty.
+	APIAddr string `json:"api_addr"`
 }
 
 func loadConfig() Config {
@@ -41,50 +72,16 @@ func loadConfig() Config {
 	return cfg
 }
 
-func fetchGoldPrices() ([]GoldPrice, error) {
-	url := "https://hoakimnguyen.com/tra-cuu-gia-vang/"
-	res, err := http.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer res.Body.Close()
-
-	if res.StatusCode != 200 {
-		return nil, fmt.Errorf("HTTP %d", res.StatusCode)
-	}
-
-	doc, err := goquery.NewDocumentFromReader(res.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	var prices []GoldPrice
-	doc.Find("table.table.table-bordered.table-hover tr").Each(func(i int, s *goquery.Selection) {
-		if s.Find("th").Length() > 0 {
-			return
-		}
-		cells := s.Find("td")
-		if cells.Length() >= 3 {
-			buy, err := convertToInt64(strings.TrimSpace(cells.Eq(1).Text()))
-			if err != nil {
-				fmt.Printf("Error: %v\n", err)
-				return
-			}
-			sell, err := convertToInt64(strings.TrimSpace(cells.Eq(2).Text()))
-			if err != nil {
-				fmt.Printf("Error: %v\n", err)
-				return
-			}
-			prices = append(prices, GoldPrice{
-				Type:      strings.TrimSpace(cells.Eq(0).Text()),
-				Buy:       *buy,
-				Sell:      *sell,
-				Converted: strings.TrimSpace(cells.Eq(3).Text()),
-				UpdatedAt: time.Now(),
-			})
-		}
-	})
-	return prices, nil
+// newSourceRegistry builds the registry of every known GoldSource,
+// applying the weights and timeout from config.
+func newSourceRegistry(cfg Config) *SourceRegistry {
+	return NewSourceRegistry([]GoldSource{
+		hoaKimNguyenSource{},
+		sjcSource{},
+		dojiSource{},
+		pnjSource{},
+		baoTinMinhChauSource{},
+	}, cfg.SourceWeights, time.Duration(cfg.SourceTimeoutMs)*time.Millisecond)
 }
 
 func saveToSQLite(prices []GoldPrice) error {
@@ -94,17 +91,7 @@ func saveToSQLite(prices []GoldPrice) error {
 	}
 	defer db.Close()
 
-	createTable := `
-	CREATE TABLE IF NOT EXISTS gold_prices (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		type TEXT,
-		buy TEXT,
-		sell TEXT,
-		converted TEXT,
-		updated_at DATETIME
-	);`
-	_, err = db.Exec(createTable)
-	if err != nil {
+	if err := migrateSQLite(db); err != nil {
 		return err
 	}
 
@@ -119,16 +106,28 @@ func saveToSQLite(prices []GoldPrice) error {
 }
 
 func sendTelegram(cfg Config, message string) {
-	if cfg.TelegramToken == "" || cfg.TelegramChatID == "" {
+	if cfg.TelegramChatID == "" {
+		return
+	}
+	sendTelegramToChat(cfg, cfg.TelegramChatID, message)
+}
+
+// sendTelegramToChat sends to an arbitrary chat ID instead of the
+// configured TelegramChatID, for per-chat /subscribe alerts.
+func sendTelegramToChat(cfg Config, chatID, message string) {
+	if cfg.TelegramToken == "" {
 		return
 	}
 
 	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", cfg.TelegramToken)
-	http.PostForm(url, map[string][]string{
-		"chat_id":    {cfg.TelegramChatID},
+	if _, err := http.PostForm(url, map[string][]string{
+		"chat_id":    {chatID},
 		"text":       {message},
 		"parse_mode": {"Markdown"},
-	})
+	}); err != nil {
+		notifyErrorsTotal.Inc()
+		log.Println("❌ Lỗi gửi Telegram:", err)
+	}
 }
 
 func sendSlack(cfg Config, message string) {
@@ -137,7 +136,10 @@ func sendSlack(cfg Config, message string) {
 	}
 
 	payload := fmt.Sprintf(`{"text": "%s"}`, strings.ReplaceAll(message, `"`, `\"`))
-	http.Post(cfg.SlackWebhook, "application/json", strings.NewReader(payload))
+	if _, err := http.Post(cfg.SlackWebhook, "application/json", strings.NewReader(payload)); err != nil {
+		notifyErrorsTotal.Inc()
+		log.Println("❌ Lỗi gửi Slack:", err)
+	}
 }
 
 func formatMessage(cfg Config, prices []GoldPrice, lastPrices []GoldPrice) (*string, error) {
@@ -146,12 +148,12 @@ func formatMessage(cfg Config, prices []GoldPrice, lastPrices []GoldPrice) (*str
 	var lastRingGold GoldPrice
 
 	for _, p := range prices {
-		if p.Type == "Vàng nhẫn khâu 9999" {
+		if p.Type == RingGoldType {
 			ringGold = p
 		}
 	}
 	for _, p := range lastPrices {
-		if p.Type == "Vàng nhẫn khâu 9999" {
+		if p.Type == RingGoldType {
 			lastRingGold = p
 		}
 	}
@@ -161,21 +163,23 @@ func formatMessage(cfg Config, prices []GoldPrice, lastPrices []GoldPrice) (*str
 	currentSell := ringGold.Sell
 	lastBuy := lastRingGold.Buy
 	lastSell := lastRingGold.Sell
-
-	switch true {
-	case currentBuy-lastBuy > 0:
-		message += fmt.Sprintf("> Hôm nay giá mua tăng %s/chỉ so với trước đó\n", FormatVND(currentBuy-lastBuy))
-	case currentBuy-lastBuy < 0:
-		message += fmt.Sprintf("> Hôm nay giá mua giảm %s/chỉ so với trước đó\n", FormatVND(currentBuy-lastBuy))
+	buyDiff := currentBuy.Sub(lastBuy)
+	sellDiff := currentSell.Sub(lastSell)
+
+	switch {
+	case buyDiff.IsPositive():
+		message += fmt.Sprintf("> Hôm nay giá mua tăng %s/chỉ so với trước đó\n", FormatVND(buyDiff))
+	case buyDiff.IsNegative():
+		message += fmt.Sprintf("> Hôm nay giá mua giảm %s/chỉ so với trước đó\n", FormatVND(buyDiff))
 	default:
 		message += fmt.Sprint("> Hôm nay giá mua không đổi so với trước đó\n")
 	}
 
-	switch true {
-	case currentSell-lastSell > 0:
-		message += fmt.Sprintf("> Hôm nay giá bán tăng %s/chỉ so với trước đó\n", FormatVND(currentSell-lastSell))
-	case currentSell-lastSell < 0:
-		message += fmt.Sprintf("> Hôm nay giá bán giảm %s/chỉ so với trước đó\n", FormatVND(currentSell-lastSell))
+	switch {
+	case sellDiff.IsPositive():
+		message += fmt.Sprintf("> Hôm nay giá bán tăng %s/chỉ so với trước đó\n", FormatVND(sellDiff))
+	case sellDiff.IsNegative():
+		message += fmt.Sprintf("> Hôm nay giá bán giảm %s/chỉ so với trước đó\n", FormatVND(sellDiff))
 	default:
 		message += fmt.Sprint("> Hôm nay giá bán không đổi so với trước đó\n")
 	}
@@ -183,33 +187,32 @@ func formatMessage(cfg Config, prices []GoldPrice, lastPrices []GoldPrice) (*str
 	return &message, nil
 }
 
-func convertToInt64(valueStr string) (*int64, error) {
+// parsePrice parses a scraped price cell such as "118,000" (thousand-VND
+// units, per chỉ) into a decimal, tolerating thousands separators.
+func parsePrice(valueStr string) (decimal.Decimal, error) {
 	valueStr = strings.ReplaceAll(valueStr, ",", "")
-
-	value, err := strconv.ParseInt(valueStr, 10, 64)
-	if err != nil {
-		return nil, err
-	}
-
-	return &value, nil
+	return decimal.NewFromString(valueStr)
 }
 
-func loadLastPrices() []GoldPrice {
+// loadLastPrices reads the last published snapshot. It returns an error
+// instead of exiting the process when latest.json is missing or
+// unreadable, since this is now reachable from the HTTP API and the
+// Telegram bot, not just the startup cron loop.
+func loadLastPrices() ([]GoldPrice, error) {
 	data, err := os.ReadFile("latest.json")
 	if err != nil {
-		log.Fatalf("Không đọc được latest.json: %v", err)
+		return nil, err
 	}
 	var prices []GoldPrice
-	json.Unmarshal(data, &prices)
-	return prices
+	if err := json.Unmarshal(data, &prices); err != nil {
+		return nil, err
+	}
+	return prices, nil
 }
 
-func FormatVND(n int64) string {
-	n = n * 1000
-	if n < 0 {
-		n *= -1
-	}
-	s := fmt.Sprintf("%d", n)
+func FormatVND(n decimal.Decimal) string {
+	n = n.Mul(decimal.NewFromInt(1000)).Abs()
+	s := n.StringFixed(0)
 	var result strings.Builder
 
 	count := 0
@@ -229,47 +232,78 @@ func FormatVND(n int64) string {
 	return string(runes) + " ₫"
 }
 
-func main() {
-	cfg := loadConfig()
+// runCycle fetches a consensus snapshot, persists it, fires any alerts,
+// and publishes the scheduled message. It's the unit of work run by
+// both the scheduler and --once.
+func runCycle(cfg Config, registry *SourceRegistry, alertManager *AlertManager, dryRun bool) {
+	prices, breakdown, err := registry.Fetch(context.Background())
+	if err != nil {
+		fetchErrorsTotal.Inc()
+		log.Println("❌ Lỗi lấy dữ liệu:", err)
+		return
+	}
+	log.Printf("ℹ️  Đồng thuận từ %d nguồn", len(breakdown))
+	recordPrices(prices)
 
-	for {
-		now := time.Now()
-		var nextRun time.Time
+	lastPrices, err := loadLastPrices()
+	if err != nil {
+		log.Println("⚠️  Không đọc được latest.json, bỏ qua so sánh với lần trước:", err)
+	}
 
-		morning := time.Date(now.Year(), now.Month(), now.Day(), 3, 0, 0, 0, now.Location())
-		afternoon := time.Date(now.Year(), now.Month(), now.Day(), 8, 0, 0, 0, now.Location())
+	data, _ := json.MarshalIndent(prices, "", "  ")
+	os.WriteFile("latest.json", data, 0644)
+	saveToSQLite(prices)
 
-		if now.Before(morning) {
-			nextRun = morning
-		} else if now.Before(afternoon) {
-			nextRun = afternoon
-		} else {
-			nextRun = morning.Add(24 * time.Hour)
-		}
+	for _, alert := range alertManager.Check(cfg, prices) {
+		sendTelegram(cfg, alert)
+		sendSlack(cfg, alert)
+	}
+	for _, alert := range alertManager.CheckSubscriptions(prices) {
+		sendTelegramToChat(cfg, alert.ChatID, alert.Message)
+	}
+
+	message, err := formatMessage(cfg, prices, lastPrices)
+	if err != nil {
+		log.Println("❌ Lỗi lấy message:", err)
+		return
+	}
+	finalMessage := appendCommentary(cfg, *message, prices, dryRun)
+	sendTelegram(cfg, finalMessage)
+	sendSlack(cfg, finalMessage)
 
-		wait := time.Until(nextRun)
-		log.Printf("⏳ Chờ tới %v để chạy cron...", nextRun.Format("15:04 02/01/2006"))
-		time.Sleep(wait)
+	log.Println("✅ Cập nhật giá vàng thành công:", time.Now())
+}
 
-		prices, err := fetchGoldPrices()
-		if err != nil {
-			log.Println("❌ Lỗi lấy dữ liệu:", err)
-			continue
-		}
+func main() {
+	once := flag.Bool("once", false, "chạy một chu kỳ lấy giá rồi thoát (dùng cho CronJob trên Kubernetes)")
+	dryRun := flag.Bool("dry-run", false, "in prompt bình luận AI ra màn hình thay vì gọi API")
+	flag.Parse()
 
-		lastPrices := loadLastPrices()
+	cfg := loadConfig()
+	registry := newSourceRegistry(cfg)
+	alertManager := NewAlertManager()
 
-		data, _ := json.MarshalIndent(prices, "", "  ")
-		os.WriteFile("latest.json", data, 0644)
-		saveToSQLite(prices)
-		message, err := formatMessage(cfg, prices, lastPrices)
-		if err != nil {
-			log.Println("❌ Lỗi lấy message:", err)
-			continue
-		}
-		sendTelegram(cfg, *message)
-		sendSlack(cfg, *message)
+	apiAddr := cfg.APIAddr
+	if apiAddr == "" {
+		apiAddr = ":9090"
+	}
+	startAPIServer(apiAddr)
+
+	if *once {
+		runCycle(cfg, registry, alertManager, *dryRun)
+		return
+	}
 
-		log.Println("✅ Cập nhật giá vàng thành công:", time.Now())
+	schedules := cfg.Schedules
+	if len(schedules) == 0 {
+		schedules = []string{"0 0 3 * * *", "0 0 8 * * *"}
 	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	go NewTelegramBot(cfg).Run(ctx)
+
+	scheduler := NewScheduler(schedules, func() { runCycle(cfg, registry, alertManager, *dryRun) })
+	scheduler.Run(ctx)
 }
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	goldBuyPrice = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gold_buy_price",
+		Help: "Giá mua vàng gần nhất theo nghìn đồng/chỉ, theo loại vàng.",
+	}, []string{"type"})
+
+	goldSellPrice = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gold_sell_price",
+		Help: "Giá bán vàng gần nhất theo nghìn đồng/chỉ, theo loại vàng.",
+	}, []string{"type"})
+
+	goldSpread = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gold_spread",
+		Help: "Chênh lệch Bán-Mua gần nhất theo nghìn đồng/chỉ, theo loại vàng.",
+	}, []string{"type"})
+
+	fetchErrorsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "fetch_errors_total",
+		Help: "Tổng số lần lấy giá vàng từ các nguồn thất bại.",
+	})
+
+	notifyErrorsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "notify_errors_total",
+		Help: "Tổng số lần gửi thông báo (Telegram/Slack) thất bại.",
+	})
+)
+
+// recordPrices updates the price gauges from a fresh consensus
+// snapshot so /metrics always reflects the last successful fetch.
+func recordPrices(prices []GoldPrice) {
+	for _, p := range prices {
+		buy, _ := p.Buy.Float64()
+		sell, _ := p.Sell.Float64()
+		goldBuyPrice.WithLabelValues(p.Type).Set(buy)
+		goldSellPrice.WithLabelValues(p.Type).Set(sell)
+		goldSpread.WithLabelValues(p.Type).Set(sell - buy)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Scheduler runs a job on every cron expression it's given and stops
+// cleanly when its context is canceled. It replaces the old
+// `for { ...; time.Sleep(wait) }` loop, which hard-coded 03:00/08:00
+// and couldn't recover from clock changes, DST, or SIGTERM.
+type Scheduler struct {
+	cron *cron.Cron
+}
+
+// NewScheduler registers job against every expression in schedules. An
+// invalid expression is logged and skipped rather than aborting startup,
+// so one typo in config doesn't take down every other schedule.
+func NewScheduler(schedules []string, job func()) *Scheduler {
+	c := cron.New(cron.WithSeconds())
+	for _, expr := range schedules {
+		if _, err := c.AddFunc(expr, job); err != nil {
+			log.Printf("⚠️  Lịch không hợp lệ %q: %v", expr, err)
+		}
+	}
+	return &Scheduler{cron: c}
+}
+
+// Run starts the scheduler and blocks until ctx is canceled, then waits
+// for any in-flight job to finish before returning.
+func (s *Scheduler) Run(ctx context.Context) {
+	s.cron.Start()
+	log.Println("⏳ Scheduler đã khởi động, chờ tới lịch chạy...")
+
+	<-ctx.Done()
+	log.Println("⏹  Nhận tín hiệu dừng, đang thoát...")
+	<-s.cron.Stop().Done()
+}
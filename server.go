@@ -0,0 +1,112 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// startAPIServer launches the HTTP API (/prices/latest, /prices/history,
+// /healthz, /metrics) as a background goroutine, so it can run
+// alongside the scheduler instead of only pushing to chat.
+func startAPIServer(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/prices/latest", handleLatestPrices)
+	mux.HandleFunc("/prices/history", handlePriceHistory)
+	mux.HandleFunc("/healthz", handleHealthz)
+	mux.Handle("/metrics", promhttp.Handler())
+
+	go func() {
+		log.Printf("🌐 API lắng nghe tại %s", addr)
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Println("❌ API server dừng:", err)
+		}
+	}()
+}
+
+func handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ok"))
+}
+
+func handleLatestPrices(w http.ResponseWriter, r *http.Request) {
+	prices, err := loadLastPrices()
+	if err != nil {
+		http.Error(w, "chưa có dữ liệu giá vàng: "+err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	writeJSON(w, prices)
+}
+
+func handlePriceHistory(w http.ResponseWriter, r *http.Request) {
+	goldType := r.URL.Query().Get("type")
+
+	from, err := parseTimeParam(r.URL.Query().Get("from"), time.Now().Add(-30*24*time.Hour))
+	if err != nil {
+		http.Error(w, "tham số from không hợp lệ: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	to, err := parseTimeParam(r.URL.Query().Get("to"), time.Now())
+	if err != nil {
+		http.Error(w, "tham số to không hợp lệ: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	prices, err := queryHistory(goldType, from, to)
+	if err != nil {
+		http.Error(w, "lỗi truy vấn lịch sử: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, prices)
+}
+
+func parseTimeParam(value string, fallback time.Time) (time.Time, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// queryHistory returns every recorded price between from and to,
+// optionally filtered to a single gold type.
+func queryHistory(goldType string, from, to time.Time) ([]GoldPrice, error) {
+	db, err := sql.Open("sqlite3", "gold.db")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	query := `SELECT type, buy, sell, converted, updated_at FROM gold_prices WHERE updated_at BETWEEN ? AND ?`
+	args := []any{from, to}
+	if goldType != "" {
+		query += ` AND type = ?`
+		args = append(args, goldType)
+	}
+	query += ` ORDER BY updated_at ASC`
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prices []GoldPrice
+	for rows.Next() {
+		var p GoldPrice
+		if err := rows.Scan(&p.Type, &p.Buy, &p.Sell, &p.Converted, &p.UpdatedAt); err != nil {
+			log.Println("⚠️  Bỏ qua dòng lịch sử không đọc được:", err)
+			continue
+		}
+		prices = append(prices, p)
+	}
+	return prices, nil
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,12 @@
+package main
+
+import "context"
+
+// baoTinMinhChauSource scrapes Bao Tin Minh Chau's public price list.
+type baoTinMinhChauSource struct{}
+
+func (baoTinMinhChauSource) Name() string { return "baotinminhchau" }
+
+func (baoTinMinhChauSource) Fetch(ctx context.Context) ([]GoldPrice, error) {
+	return fetchTablePrices(ctx, "https://btmc.vn/bang-gia-vang", "table.bang-gia-vang tr")
+}
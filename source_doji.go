@@ -0,0 +1,12 @@
+package main
+
+import "context"
+
+// dojiSource scrapes DOJI's public price list.
+type dojiSource struct{}
+
+func (dojiSource) Name() string { return "doji" }
+
+func (dojiSource) Fetch(ctx context.Context) ([]GoldPrice, error) {
+	return fetchTablePrices(ctx, "https://update.giavang.doji.vn/banggia/doji", "table.table-price tr")
+}
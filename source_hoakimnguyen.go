@@ -0,0 +1,14 @@
+package main
+
+import "context"
+
+// hoaKimNguyenSource scrapes https://hoakimnguyen.com/tra-cuu-gia-vang/.
+// This is the tracker's original source, kept as the highest-weighted
+// default in config.
+type hoaKimNguyenSource struct{}
+
+func (hoaKimNguyenSource) Name() string { return "hoakimnguyen" }
+
+func (hoaKimNguyenSource) Fetch(ctx context.Context) ([]GoldPrice, error) {
+	return fetchTablePrices(ctx, "https://hoakimnguyen.com/tra-cuu-gia-vang/", "table.table.table-bordered.table-hover tr")
+}
@@ -0,0 +1,12 @@
+package main
+
+import "context"
+
+// pnjSource scrapes PNJ's public price list.
+type pnjSource struct{}
+
+func (pnjSource) Name() string { return "pnj" }
+
+func (pnjSource) Fetch(ctx context.Context) ([]GoldPrice, error) {
+	return fetchTablePrices(ctx, "https://www.pnj.com.vn/blog/gia-vang/", "table.table-gia-vang tr")
+}
@@ -0,0 +1,12 @@
+package main
+
+import "context"
+
+// sjcSource scrapes SJC's public price list.
+type sjcSource struct{}
+
+func (sjcSource) Name() string { return "sjc" }
+
+func (sjcSource) Fetch(ctx context.Context) ([]GoldPrice, error) {
+	return fetchTablePrices(ctx, "https://sjc.com.vn/giavang/textContent.php", "table.bor tr")
+}
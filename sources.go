@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/shopspring/decimal"
+)
+
+// GoldSource fetches a snapshot of gold prices from a single exchange or
+// vendor website. Implementations should not retry internally; the
+// SourceRegistry owns timeouts and retries across the whole fan-out.
+type GoldSource interface {
+	// Name identifies the source for config weighting and breakdown output.
+	Name() string
+	Fetch(ctx context.Context) ([]GoldPrice, error)
+}
+
+// SourceRegistry fans a fetch out across every registered GoldSource
+// concurrently, drops sources that error out or exceed the per-source
+// timeout, and folds whatever is left into a consensus price per gold
+// type. This is what lets the tracker keep publishing when one site
+// changes its HTML: the other sources carry the consensus.
+type SourceRegistry struct {
+	sources []GoldSource
+	weights map[string]float64
+	timeout time.Duration
+}
+
+// NewSourceRegistry builds a registry over sources. weights maps a
+// source's Name() to its influence on the consensus median; sources
+// missing from the map default to a weight of 1. A non-positive timeout
+// falls back to 5s.
+func NewSourceRegistry(sources []GoldSource, weights map[string]float64, timeout time.Duration) *SourceRegistry {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &SourceRegistry{sources: sources, weights: weights, timeout: timeout}
+}
+
+type sourceResult struct {
+	name   string
+	prices []GoldPrice
+	err    error
+}
+
+// Fetch queries every source concurrently and returns the consensus
+// prices alongside the raw per-source breakdown (keyed by source name)
+// so callers can see what was excluded and why.
+func (r *SourceRegistry) Fetch(ctx context.Context) (consensus []GoldPrice, breakdown map[string][]GoldPrice, err error) {
+	results := make([]sourceResult, len(r.sources))
+	var wg sync.WaitGroup
+
+	for i, src := range r.sources {
+		wg.Add(1)
+		go func(i int, src GoldSource) {
+			defer wg.Done()
+			cctx, cancel := context.WithTimeout(ctx, r.timeout)
+			defer cancel()
+			prices, ferr := src.Fetch(cctx)
+			results[i] = sourceResult{name: src.Name(), prices: prices, err: ferr}
+		}(i, src)
+	}
+	wg.Wait()
+
+	breakdown = make(map[string][]GoldPrice, len(results))
+	byType := make(map[string][]weightedPrice)
+	var okCount int
+
+	for _, res := range results {
+		breakdown[res.name] = res.prices
+		if res.err != nil {
+			log.Printf("⚠️  Nguồn %s lỗi: %v", res.name, res.err)
+			continue
+		}
+		okCount++
+		weight := r.weights[res.name]
+		if weight <= 0 {
+			weight = 1
+		}
+		for _, p := range res.prices {
+			p.Type = canonicalGoldType(res.name, p.Type)
+			byType[p.Type] = append(byType[p.Type], weightedPrice{price: p, weight: weight})
+		}
+	}
+
+	if okCount == 0 {
+		return nil, breakdown, fmt.Errorf("tất cả nguồn giá vàng đều lỗi")
+	}
+
+	types := make([]string, 0, len(byType))
+	for t := range byType {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	for _, t := range types {
+		consensus = append(consensus, consensusPrice(t, byType[t]))
+	}
+	return consensus, breakdown, nil
+}
+
+type weightedPrice struct {
+	price  GoldPrice
+	weight float64
+}
+
+// consensusPrice folds every source's quote for one gold type into a
+// single weighted-median price.
+func consensusPrice(goldType string, quotes []weightedPrice) GoldPrice {
+	buys := make([]weightedValue, len(quotes))
+	sells := make([]weightedValue, len(quotes))
+	for i, q := range quotes {
+		buys[i] = weightedValue{value: q.price.Buy, weight: q.weight}
+		sells[i] = weightedValue{value: q.price.Sell, weight: q.weight}
+	}
+
+	return GoldPrice{
+		Type:      goldType,
+		Buy:       weightedMedian(buys),
+		Sell:      weightedMedian(sells),
+		Converted: quotes[0].price.Converted,
+		UpdatedAt: time.Now(),
+	}
+}
+
+type weightedValue struct {
+	value  decimal.Decimal
+	weight float64
+}
+
+// weightedMedian returns the value at the 50th percentile of weight
+// mass. With equal weights this is a plain median; a higher-weighted
+// source pulls the result toward its own quote.
+func weightedMedian(values []weightedValue) decimal.Decimal {
+	if len(values) == 0 {
+		return decimal.Zero
+	}
+	sorted := append([]weightedValue(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].value.LessThan(sorted[j].value) })
+
+	var total float64
+	for _, v := range sorted {
+		total += v.weight
+	}
+	if total == 0 {
+		return sorted[len(sorted)/2].value
+	}
+
+	var cum float64
+	for _, v := range sorted {
+		cum += v.weight
+		if cum >= total/2 {
+			return v.value
+		}
+	}
+	return sorted[len(sorted)-1].value
+}
+
+// fetchTablePrices performs a GET against url and parses a price table
+// where each matched row has [type, buy, sell, converted] cells. It's
+// shared by every GoldSource since the tracked exchanges all publish
+// their rates as a plain HTML table.
+func fetchTablePrices(ctx context.Context, url, rowSelector string) ([]GoldPrice, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != 200 {
+		return nil, fmt.Errorf("HTTP %d", res.StatusCode)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var prices []GoldPrice
+	doc.Find(rowSelector).Each(func(i int, s *goquery.Selection) {
+		if s.Find("th").Length() > 0 {
+			return
+		}
+		cells := s.Find("td")
+		if cells.Length() < 3 {
+			return
+		}
+		buy, err := parsePrice(strings.TrimSpace(cells.Eq(1).Text()))
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		sell, err := parsePrice(strings.TrimSpace(cells.Eq(2).Text()))
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		prices = append(prices, GoldPrice{
+			Type:      strings.TrimSpace(cells.Eq(0).Text()),
+			Buy:       buy,
+			Sell:      sell,
+			Converted: strings.TrimSpace(cells.Eq(3).Text()),
+			UpdatedAt: time.Now(),
+		})
+	})
+	return prices, nil
+}
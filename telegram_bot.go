@@ -0,0 +1,383 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	chart "github.com/wcharczuk/go-chart/v2"
+)
+
+// TelegramBot long-polls Telegram's getUpdates endpoint and answers
+// slash commands from TelegramChatID, turning the one-way notifier into
+// a two-way tool without touching the scheduled cron publish.
+type TelegramBot struct {
+	cfg Config
+}
+
+func NewTelegramBot(cfg Config) *TelegramBot {
+	return &TelegramBot{cfg: cfg}
+}
+
+type tgUpdate struct {
+	UpdateID int       `json:"update_id"`
+	Message  tgMessage `json:"message"`
+}
+
+type tgMessage struct {
+	Chat tgChat `json:"chat"`
+	Text string `json:"text"`
+}
+
+type tgChat struct {
+	ID int64 `json:"id"`
+}
+
+// Run polls for updates until ctx is canceled. It's a no-op if
+// TelegramToken isn't configured.
+func (b *TelegramBot) Run(ctx context.Context) {
+	if b.cfg.TelegramToken == "" {
+		return
+	}
+	if err := ensureSubscriptionsTable(); err != nil {
+		log.Println("❌ Không tạo được bảng subscriptions:", err)
+		return
+	}
+
+	offset := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		updates, err := b.getUpdates(offset)
+		if err != nil {
+			log.Println("⚠️  Lỗi getUpdates:", err)
+			time.Sleep(5 * time.Second)
+			continue
+		}
+
+		for _, u := range updates {
+			offset = u.UpdateID + 1
+			b.handleMessage(u.Message)
+		}
+	}
+}
+
+func (b *TelegramBot) getUpdates(offset int) ([]tgUpdate, error) {
+	reqURL := fmt.Sprintf("https://api.telegram.org/bot%s/getUpdates?timeout=30&offset=%d", b.cfg.TelegramToken, offset)
+	res, err := http.Get(reqURL)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var payload struct {
+		OK     bool       `json:"ok"`
+		Result []tgUpdate `json:"result"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&payload); err != nil {
+		return nil, err
+	}
+	return payload.Result, nil
+}
+
+func (b *TelegramBot) handleMessage(msg tgMessage) {
+	fields := strings.Fields(msg.Text)
+	if len(fields) == 0 {
+		return
+	}
+	chatID := strconv.FormatInt(msg.Chat.ID, 10)
+	if chatID != b.cfg.TelegramChatID {
+		return
+	}
+	cmd, args := fields[0], fields[1:]
+
+	switch cmd {
+	case "/price":
+		b.replyPrice(chatID, args)
+	case "/chart":
+		b.replyChart(chatID, args)
+	case "/subscribe":
+		b.replySubscribe(chatID, args)
+	case "/history":
+		b.replyHistory(chatID, args)
+	}
+}
+
+func (b *TelegramBot) replyPrice(chatID string, args []string) {
+	prices, err := loadLastPrices()
+	if err != nil {
+		b.sendText(chatID, "Chưa có dữ liệu giá vàng, thử lại sau.")
+		return
+	}
+
+	if len(args) > 0 {
+		goldType := strings.Join(args, " ")
+		for _, p := range prices {
+			if strings.EqualFold(p.Type, goldType) {
+				b.sendText(chatID, fmt.Sprintf("%s: Mua %s/chỉ - Bán %s/chỉ", p.Type, FormatVND(p.Buy), FormatVND(p.Sell)))
+				return
+			}
+		}
+		b.sendText(chatID, "Không tìm thấy loại vàng: "+goldType)
+		return
+	}
+
+	var lines []string
+	for _, p := range prices {
+		lines = append(lines, fmt.Sprintf("• %s: Mua %s/chỉ - Bán %s/chỉ", p.Type, FormatVND(p.Buy), FormatVND(p.Sell)))
+	}
+	b.sendText(chatID, strings.Join(lines, "\n"))
+}
+
+func (b *TelegramBot) replyChart(chatID string, args []string) {
+	days := 7
+	if len(args) > 0 {
+		if d, err := parseDaySpec(args[0]); err == nil {
+			days = d
+		}
+	}
+
+	const goldType = RingGoldType
+	prices, err := queryHistory(goldType, time.Now().AddDate(0, 0, -days), time.Now())
+	if err != nil || len(prices) == 0 {
+		b.sendText(chatID, "Không có đủ dữ liệu lịch sử để vẽ biểu đồ.")
+		return
+	}
+
+	png, err := renderPriceChart(goldType, prices)
+	if err != nil {
+		b.sendText(chatID, "Lỗi vẽ biểu đồ: "+err.Error())
+		return
+	}
+	if err := b.sendPhoto(chatID, fmt.Sprintf("%s - %d ngày qua", goldType, days), png); err != nil {
+		notifyErrorsTotal.Inc()
+		log.Println("❌ Lỗi gửi biểu đồ:", err)
+	}
+}
+
+func (b *TelegramBot) replySubscribe(chatID string, args []string) {
+	if len(args) < 2 {
+		b.sendText(chatID, "Cách dùng: /subscribe <loại vàng> <ngưỡng, nghìn đồng/chỉ>")
+		return
+	}
+	threshold, err := decimal.NewFromString(args[len(args)-1])
+	if err != nil {
+		b.sendText(chatID, "Ngưỡng không hợp lệ: "+args[len(args)-1])
+		return
+	}
+
+	// Gold types are multi-word Vietnamese labels, so match the same
+	// way replyPrice does instead of just taking args[0] — otherwise a
+	// subscription is saved against a type that never matches the
+	// exact-key lookup in CheckSubscriptions and silently never fires.
+	goldTypeArg := strings.Join(args[:len(args)-1], " ")
+	prices, err := loadLastPrices()
+	if err != nil {
+		b.sendText(chatID, "Chưa có dữ liệu giá vàng, thử lại sau.")
+		return
+	}
+	var goldType string
+	for _, p := range prices {
+		if strings.EqualFold(p.Type, goldTypeArg) {
+			goldType = p.Type
+			break
+		}
+	}
+	if goldType == "" {
+		b.sendText(chatID, "Không tìm thấy loại vàng: "+goldTypeArg)
+		return
+	}
+
+	if err := saveSubscription(chatID, goldType, threshold); err != nil {
+		b.sendText(chatID, "Lỗi lưu đăng ký: "+err.Error())
+		return
+	}
+	b.sendText(chatID, fmt.Sprintf("✅ Đã đăng ký cảnh báo %s khi biến động vượt %s", goldType, FormatVND(threshold)))
+}
+
+func (b *TelegramBot) replyHistory(chatID string, args []string) {
+	days := 30
+	if len(args) > 0 {
+		if d, err := parseDaySpec(args[0]); err == nil {
+			days = d
+		}
+	}
+
+	prices, err := queryHistory("", time.Now().AddDate(0, 0, -days), time.Now())
+	if err != nil {
+		b.sendText(chatID, "Lỗi truy vấn lịch sử: "+err.Error())
+		return
+	}
+
+	csv := buildHistoryCSV(prices)
+	if err := b.sendDocument(chatID, fmt.Sprintf("lich-su-%dngay.csv", days), []byte(csv)); err != nil {
+		notifyErrorsTotal.Inc()
+		log.Println("❌ Lỗi gửi CSV:", err)
+	}
+}
+
+func parseDaySpec(spec string) (int, error) {
+	return strconv.Atoi(strings.TrimSuffix(spec, "d"))
+}
+
+func buildHistoryCSV(prices []GoldPrice) string {
+	var b strings.Builder
+	b.WriteString("type,buy,sell,converted,updated_at\n")
+	for _, p := range prices {
+		fmt.Fprintf(&b, "%s,%s,%s,%s,%s\n", p.Type, p.Buy.String(), p.Sell.String(), p.Converted, p.UpdatedAt.Format(time.RFC3339))
+	}
+	return b.String()
+}
+
+// renderPriceChart draws a buy/sell line chart for one gold type.
+func renderPriceChart(goldType string, prices []GoldPrice) ([]byte, error) {
+	xs := make([]time.Time, len(prices))
+	buys := make([]float64, len(prices))
+	sells := make([]float64, len(prices))
+	for i, p := range prices {
+		xs[i] = p.UpdatedAt
+		buys[i], _ = p.Buy.Float64()
+		sells[i], _ = p.Sell.Float64()
+	}
+
+	graph := chart.Chart{
+		Title: goldType,
+		Series: []chart.Series{
+			chart.TimeSeries{Name: "Mua", XValues: xs, YValues: buys},
+			chart.TimeSeries{Name: "Bán", XValues: xs, YValues: sells},
+		},
+	}
+	graph.Elements = []chart.Renderable{chart.Legend(&graph)}
+
+	var buf bytes.Buffer
+	if err := graph.Render(chart.PNG, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (b *TelegramBot) sendText(chatID, text string) {
+	reqURL := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", b.cfg.TelegramToken)
+	if _, err := http.PostForm(reqURL, url.Values{
+		"chat_id":    {chatID},
+		"text":       {text},
+		"parse_mode": {"Markdown"},
+	}); err != nil {
+		notifyErrorsTotal.Inc()
+		log.Println("❌ Lỗi gửi Telegram:", err)
+	}
+}
+
+func (b *TelegramBot) sendPhoto(chatID, caption string, png []byte) error {
+	return b.sendFile(chatID, "sendPhoto", "photo", "chart.png", caption, png)
+}
+
+func (b *TelegramBot) sendDocument(chatID, filename string, content []byte) error {
+	return b.sendFile(chatID, "sendDocument", "document", filename, "", content)
+}
+
+func (b *TelegramBot) sendFile(chatID, method, field, filename, caption string, content []byte) error {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	writer.WriteField("chat_id", chatID)
+	if caption != "" {
+		writer.WriteField("caption", caption)
+	}
+	part, err := writer.CreateFormFile(field, filename)
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(content); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	reqURL := fmt.Sprintf("https://api.telegram.org/bot%s/%s", b.cfg.TelegramToken, method)
+	req, err := http.NewRequest(http.MethodPost, reqURL, &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	_, err = http.DefaultClient.Do(req)
+	return err
+}
+
+func ensureSubscriptionsTable() error {
+	db, err := sql.Open("sqlite3", "gold.db")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS subscriptions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		chat_id TEXT NOT NULL,
+		gold_type TEXT NOT NULL,
+		threshold_vnd NUMERIC NOT NULL,
+		created_at DATETIME NOT NULL
+	);`)
+	return err
+}
+
+func saveSubscription(chatID, goldType string, threshold decimal.Decimal) error {
+	db, err := sql.Open("sqlite3", "gold.db")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`INSERT INTO subscriptions (chat_id, gold_type, threshold_vnd, created_at) VALUES (?, ?, ?, ?)`,
+		chatID, goldType, threshold, time.Now())
+	return err
+}
+
+// subscription is one row registered via /subscribe: alert chatID when
+// goldType's buy price moves at least Threshold (thousand-VND units)
+// over AlertManager.CheckSubscriptions' fixed lookback window.
+type subscription struct {
+	ChatID    string
+	GoldType  string
+	Threshold decimal.Decimal
+}
+
+func loadSubscriptions() ([]subscription, error) {
+	db, err := sql.Open("sqlite3", "gold.db")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`SELECT chat_id, gold_type, threshold_vnd FROM subscriptions`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []subscription
+	for rows.Next() {
+		var s subscription
+		if err := rows.Scan(&s.ChatID, &s.GoldType, &s.Threshold); err != nil {
+			return nil, err
+		}
+		subs = append(subs, s)
+	}
+	return subs, rows.Err()
+}